@@ -61,6 +61,12 @@ type Metrics struct {
 	FollowersConnected          prometheus.Gauge
 	LeaderConnected             prometheus.Gauge
 
+	// Write-behind log metrics
+	WBLEntriesTotal    prometheus.Gauge
+	WBLSizeBytes       prometheus.Gauge
+	WBLFlushesTotal    prometheus.Counter
+	WBLOutOfOrderTotal *prometheus.CounterVec
+
 	// Migration metrics
 	MigrationDuration     *prometheus.HistogramVec
 	MigrationTotal        *prometheus.CounterVec
@@ -357,6 +363,35 @@ func New(namespace string) *Metrics {
 				Help:      "Size of the Write-Ahead Log in bytes",
 			},
 		),
+		WBLEntriesTotal: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "wbl_entries_total",
+				Help:      "Total entries in the Write-Behind Log",
+			},
+		),
+		WBLSizeBytes: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "wbl_size_bytes",
+				Help:      "Size of the Write-Behind Log in bytes",
+			},
+		),
+		WBLFlushesTotal: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "wbl_flushes_total",
+				Help:      "Total background fsync flushes of the Write-Behind Log",
+			},
+		),
+		WBLOutOfOrderTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "wbl_out_of_order_total",
+				Help:      "Total out-of-order entries appended to the Write-Behind Log",
+			},
+			[]string{"tenant_id"},
+		),
 		FollowersConnected: promauto.NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -581,6 +616,22 @@ func (m *Metrics) SetWALStats(entries int64, sizeBytes int64) {
 	m.WALSizeBytes.Set(float64(sizeBytes))
 }
 
+// SetWBLStats sets Write-Behind Log statistics.
+func (m *Metrics) SetWBLStats(entries int64, sizeBytes int64) {
+	m.WBLEntriesTotal.Set(float64(entries))
+	m.WBLSizeBytes.Set(float64(sizeBytes))
+}
+
+// RecordWBLFlush records a background fsync flush of the Write-Behind Log.
+func (m *Metrics) RecordWBLFlush() {
+	m.WBLFlushesTotal.Inc()
+}
+
+// RecordWBLOutOfOrder records an out-of-order append to the Write-Behind Log.
+func (m *Metrics) RecordWBLOutOfOrder(tenantID string) {
+	m.WBLOutOfOrderTotal.WithLabelValues(tenantID).Inc()
+}
+
 // SetFollowersConnected sets the number of connected followers.
 func (m *Metrics) SetFollowersConnected(count int) {
 	m.FollowersConnected.Set(float64(count))
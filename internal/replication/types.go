@@ -13,18 +13,23 @@ import (
 
 // Common errors for replication operations.
 var (
-	ErrNotLeader           = errors.New("operation requires leader role")
-	ErrNotFollower         = errors.New("operation requires follower role")
-	ErrLeaderUnavailable   = errors.New("leader is unavailable")
-	ErrReplicationLag      = errors.New("replication lag exceeds threshold")
-	ErrConflict            = errors.New("write conflict detected")
-	ErrInvalidWALEntry     = errors.New("invalid WAL entry")
-	ErrChecksumMismatch    = errors.New("WAL entry checksum mismatch")
-	ErrWALClosed           = errors.New("WAL is closed")
-	ErrFollowerNotFound    = errors.New("follower not found")
-	ErrInvalidPlacement    = errors.New("invalid tenant placement")
-	ErrRegionNotAvailable  = errors.New("region not available")
-	ErrTenantNotReplicated = errors.New("tenant is not configured for replication")
+	ErrNotLeader                 = errors.New("operation requires leader role")
+	ErrNotFollower               = errors.New("operation requires follower role")
+	ErrLeaderUnavailable         = errors.New("leader is unavailable")
+	ErrReplicationLag            = errors.New("replication lag exceeds threshold")
+	ErrConflict                  = errors.New("write conflict detected")
+	ErrInvalidWALEntry           = errors.New("invalid WAL entry")
+	ErrChecksumMismatch          = errors.New("WAL entry checksum mismatch")
+	ErrWALClosed                 = errors.New("WAL is closed")
+	ErrFollowerNotFound          = errors.New("follower not found")
+	ErrInvalidPlacement          = errors.New("invalid tenant placement")
+	ErrRegionNotAvailable        = errors.New("region not available")
+	ErrTenantNotReplicated       = errors.New("tenant is not configured for replication")
+	ErrTruncateBlockedByConsumer = errors.New("truncation blocked by unacknowledged WAL consumer")
+	ErrChecksumChainBroken       = errors.New("WAL entry checksum chain is broken")
+	ErrSnapshotChecksumMismatch  = errors.New("snapshot checksum root mismatch")
+	ErrNotWriteBehind            = errors.New("operation requires a write-behind WAL")
+	ErrQuotaExceeded             = errors.New("tenant WAL quota exceeded")
 )
 
 // Role defines the replication role of a MAIA instance.
@@ -140,6 +145,10 @@ type WALEntry struct {
 	// Checksum is CRC32 for integrity verification.
 	Checksum uint32 `json:"checksum"`
 
+	// PrevChecksum is the checksum of the previous entry in sequence order,
+	// forming a hash chain that lets a restore detect tampering or gaps.
+	PrevChecksum uint64 `json:"prev_checksum"`
+
 	// Region is the region where this write originated.
 	Region string `json:"region"`
 
@@ -169,6 +178,36 @@ func (e *WALEntry) Validate() error {
 
 // ComputeChecksum calculates the CRC32 checksum for the entry.
 func (e *WALEntry) ComputeChecksum() uint32 {
+	data, _ := json.Marshal(struct {
+		ID           string       `json:"id"`
+		Sequence     uint64       `json:"sequence"`
+		Timestamp    time.Time    `json:"timestamp"`
+		TenantID     string       `json:"tenant_id"`
+		Operation    Operation    `json:"operation"`
+		ResourceType ResourceType `json:"resource_type"`
+		ResourceID   string       `json:"resource_id"`
+		Namespace    string       `json:"namespace,omitempty"`
+		Data         []byte       `json:"data,omitempty"`
+		PrevChecksum uint64       `json:"prev_checksum"`
+	}{
+		ID:           e.ID,
+		Sequence:     e.Sequence,
+		Timestamp:    e.Timestamp,
+		TenantID:     e.TenantID,
+		Operation:    e.Operation,
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		Namespace:    e.Namespace,
+		Data:         e.Data,
+		PrevChecksum: e.PrevChecksum,
+	})
+	return crc32.ChecksumIEEE(data)
+}
+
+// legacyChecksum reproduces the checksum payload used before PrevChecksum
+// existed, so VerifyChecksum can still validate entries written by older
+// versions of this package.
+func (e *WALEntry) legacyChecksum() uint32 {
 	data, _ := json.Marshal(struct {
 		ID           string       `json:"id"`
 		Sequence     uint64       `json:"sequence"`
@@ -193,9 +232,15 @@ func (e *WALEntry) ComputeChecksum() uint32 {
 	return crc32.ChecksumIEEE(data)
 }
 
-// VerifyChecksum validates the entry checksum.
+// VerifyChecksum validates the entry checksum. Entries written before the
+// PrevChecksum hash chain was introduced were checksummed over a narrower
+// payload, so a mismatch against the current format falls back to that
+// legacy payload before the entry is treated as corrupt.
 func (e *WALEntry) VerifyChecksum() bool {
-	return e.Checksum == e.ComputeChecksum()
+	if e.Checksum == e.ComputeChecksum() {
+		return true
+	}
+	return e.Checksum == e.legacyChecksum()
 }
 
 // WALPosition represents a position in the WAL.
@@ -210,6 +255,22 @@ type WALPosition struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// WALMode selects whether a BadgerWAL behaves as a Write-Ahead Log or a
+// Write-Behind Log.
+type WALMode int
+
+const (
+	// WALModeWriteAhead durably appends entries in strict arrival order
+	// before the caller's write is considered complete. This is the
+	// default and matches the WAL interface's ordering guarantees.
+	WALModeWriteAhead WALMode = iota
+
+	// WALModeWriteBehind returns from Append as soon as the entry is
+	// buffered, batching fsyncs on an interval, and accepts out-of-order
+	// entries via AppendOutOfOrder for late-replicated writes.
+	WALModeWriteBehind
+)
+
 // WAL provides write-ahead logging for replication.
 type WAL interface {
 	// Append adds an entry to the log.
@@ -13,16 +13,24 @@ import (
 	"github.com/dgraph-io/badger/v4"
 	"github.com/oklog/ulid/v2"
 	"go.uber.org/zap"
+
+	"github.com/ar4mirez/maia/internal/metrics"
 )
 
 const (
 	// WAL key prefixes
-	walEntryPrefix    = "wal:"     // wal:{id} -> WALEntry
-	walSequencePrefix = "walseq:"  // walseq:{sequence} -> entry ID
-	walMetaKey        = "wal:meta" // metadata (current sequence, etc.)
+	walEntryPrefix    = "wal:"           // wal:{id} -> WALEntry
+	walSequencePrefix = "walseq:"        // walseq:{sequence} -> entry ID
+	walMetaKey        = "wal:meta"       // metadata (current sequence, etc.)
+	walConsumerPrefix = "wal-consumers:" // wal-consumers:{consumerID} -> consumerPosition
+	walOOPrefix       = "wal-oo:"        // wal-oo:{tenant}:{seq} -> entry ID (WBL out-of-order set)
 
 	// Default batch size for reads
 	defaultReadBatchSize = 100
+
+	// defaultWBLFlushInterval is how often a WALModeWriteBehind log fsyncs
+	// buffered writes when BadgerWALOptions.FlushInterval is unset.
+	defaultWBLFlushInterval = 200 * time.Millisecond
 )
 
 // walMeta stores WAL metadata.
@@ -31,18 +39,48 @@ type walMeta struct {
 	OldestSequence  uint64    `json:"oldest_sequence"`
 	EntryCount      int64     `json:"entry_count"`
 	TotalBytes      int64     `json:"total_bytes"`
+	LastChecksum    uint32    `json:"last_checksum"`
 	LastCompaction  time.Time `json:"last_compaction,omitempty"`
 }
 
 // BadgerWAL implements WAL using BadgerDB.
 type BadgerWAL struct {
-	db       *badger.DB
-	logger   *zap.Logger
-	region   string
-	entropy  *ulid.MonotonicEntropy
-	sequence atomic.Uint64
-	mu       sync.RWMutex
-	closed   atomic.Bool
+	db           *badger.DB
+	logger       *zap.Logger
+	region       string
+	entropy      *ulid.MonotonicEntropy
+	sequence     atomic.Uint64
+	lastChecksum atomic.Uint32
+	mu           sync.RWMutex
+	closed       atomic.Bool
+
+	// entryCount/totalBytes track the WAL's size incrementally, updated in
+	// the same transaction as each Append, so callers like quota checks
+	// can read them without a full keyspace scan.
+	entryCount atomic.Int64
+	totalBytes atomic.Int64
+
+	// notifyMu/notifyCond coalesce wake-ups for watchers tailing the log.
+	// notifyVersion increments under notifyMu on every Append, so a watcher
+	// can compare it against the value it saw before an empty Read and
+	// tell whether a Broadcast happened in between, instead of racing a
+	// check-then-wait against Append's Broadcast.
+	notifyMu      sync.Mutex
+	notifyCond    *sync.Cond
+	notifyVersion uint64
+
+	// watcherMu/watchers track every live Watcher so Close can stop them;
+	// otherwise a Watcher created with a long-lived context would block in
+	// notifyCond.Wait() forever after the WAL it tails is closed.
+	watcherMu sync.Mutex
+	watchers  map[*Watcher]struct{}
+
+	mode    WALMode
+	metrics *metrics.Metrics
+
+	flushInterval time.Duration
+	flusherStop   chan struct{}
+	flusherDone   chan struct{}
 }
 
 // newEntropy creates a new monotonic entropy source for ULID generation.
@@ -61,11 +99,27 @@ type BadgerWALOptions struct {
 	// Logger is the logger to use.
 	Logger *zap.Logger
 
-	// SyncWrites enables synchronous writes for durability.
+	// SyncWrites enables synchronous writes for durability. Ignored in
+	// WALModeWriteBehind, which always batches fsyncs via FlushInterval.
 	SyncWrites bool
 
 	// ValueLogFileSize is the size of value log files.
 	ValueLogFileSize int64
+
+	// Mode selects Write-Ahead vs Write-Behind behavior. Defaults to
+	// WALModeWriteAhead.
+	Mode WALMode
+
+	// FlushInterval is how often a WALModeWriteBehind log fsyncs buffered
+	// writes. Defaults to defaultWBLFlushInterval. Ignored in
+	// WALModeWriteAhead.
+	FlushInterval time.Duration
+
+	// Metrics, if set, receives the wbl_flushes_total and
+	// wbl_out_of_order_total counters in WALModeWriteBehind. Nothing is
+	// recorded in WALModeWriteAhead, and the WAL and WBL entry and size
+	// gauges are not updated.
+	Metrics *metrics.Metrics
 }
 
 // NewBadgerWAL creates a new BadgerDB-backed WAL.
@@ -80,7 +134,7 @@ func NewBadgerWAL(opts *BadgerWALOptions) (*BadgerWAL, error) {
 	}
 
 	badgerOpts := badger.DefaultOptions(opts.DataDir)
-	badgerOpts.SyncWrites = opts.SyncWrites
+	badgerOpts.SyncWrites = opts.SyncWrites && opts.Mode != WALModeWriteBehind
 	badgerOpts.Logger = nil // Disable Badger's default logging
 
 	if opts.ValueLogFileSize > 0 {
@@ -94,12 +148,22 @@ func NewBadgerWAL(opts *BadgerWALOptions) (*BadgerWAL, error) {
 		return nil, fmt.Errorf("failed to open WAL database: %w", err)
 	}
 
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultWBLFlushInterval
+	}
+
 	w := &BadgerWAL{
-		db:      db,
-		logger:  logger,
-		region:  opts.Region,
-		entropy: newEntropy(),
+		db:            db,
+		logger:        logger,
+		region:        opts.Region,
+		entropy:       newEntropy(),
+		mode:          opts.Mode,
+		metrics:       opts.Metrics,
+		flushInterval: flushInterval,
 	}
+	w.notifyCond = sync.NewCond(&w.notifyMu)
+	w.watchers = make(map[*Watcher]struct{})
 
 	// Load current sequence from metadata
 	if err := w.loadMeta(); err != nil {
@@ -107,9 +171,40 @@ func NewBadgerWAL(opts *BadgerWALOptions) (*BadgerWAL, error) {
 		return nil, fmt.Errorf("failed to load WAL metadata: %w", err)
 	}
 
+	if w.mode == WALModeWriteBehind {
+		w.flusherStop = make(chan struct{})
+		w.flusherDone = make(chan struct{})
+		go w.runFlusher()
+	}
+
 	return w, nil
 }
 
+// runFlusher periodically fsyncs the database for a WALModeWriteBehind log,
+// batching the durability cost of many buffered Append calls into one
+// syscall per interval.
+func (w *BadgerWAL) runFlusher() {
+	defer close(w.flusherDone)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.flusherStop:
+			return
+		case <-ticker.C:
+			if err := w.db.Sync(); err != nil {
+				w.logger.Warn("WBL background flush failed", zap.Error(err))
+				continue
+			}
+			if w.metrics != nil {
+				w.metrics.RecordWBLFlush()
+			}
+		}
+	}
+}
+
 // loadMeta loads WAL metadata from the database.
 func (w *BadgerWAL) loadMeta() error {
 	return w.db.View(func(txn *badger.Txn) error {
@@ -129,6 +224,9 @@ func (w *BadgerWAL) loadMeta() error {
 				return err
 			}
 			w.sequence.Store(meta.CurrentSequence)
+			w.lastChecksum.Store(meta.LastChecksum)
+			w.entryCount.Store(meta.EntryCount)
+			w.totalBytes.Store(meta.TotalBytes)
 			return nil
 		})
 	})
@@ -170,7 +268,8 @@ func (w *BadgerWAL) Append(ctx context.Context, entry *WALEntry) error {
 		entry.Region = w.region
 	}
 
-	// Compute checksum
+	// Chain to the previous entry's checksum and compute this one's.
+	entry.PrevChecksum = uint64(w.lastChecksum.Load())
 	entry.Checksum = entry.ComputeChecksum()
 
 	// Validate entry
@@ -184,6 +283,9 @@ func (w *BadgerWAL) Append(ctx context.Context, entry *WALEntry) error {
 		return fmt.Errorf("failed to marshal WAL entry: %w", err)
 	}
 
+	newEntryCount := w.entryCount.Load() + 1
+	newTotalBytes := w.totalBytes.Load() + int64(len(data))
+
 	// Write to database
 	err = w.db.Update(func(txn *badger.Txn) error {
 		// Store entry by ID
@@ -201,8 +303,9 @@ func (w *BadgerWAL) Append(ctx context.Context, entry *WALEntry) error {
 		// Update metadata
 		meta := &walMeta{
 			CurrentSequence: entry.Sequence,
-			EntryCount:      0, // Will be computed on read
-			TotalBytes:      0, // Will be computed on read
+			EntryCount:      newEntryCount,
+			TotalBytes:      newTotalBytes,
+			LastChecksum:    entry.Checksum,
 		}
 		return w.saveMeta(txn, meta)
 	})
@@ -211,6 +314,10 @@ func (w *BadgerWAL) Append(ctx context.Context, entry *WALEntry) error {
 		return fmt.Errorf("failed to append WAL entry: %w", err)
 	}
 
+	w.lastChecksum.Store(entry.Checksum)
+	w.entryCount.Store(newEntryCount)
+	w.totalBytes.Store(newTotalBytes)
+
 	w.logger.Debug("appended WAL entry",
 		zap.String("id", entry.ID),
 		zap.Uint64("sequence", entry.Sequence),
@@ -219,6 +326,12 @@ func (w *BadgerWAL) Append(ctx context.Context, entry *WALEntry) error {
 		zap.String("resource_id", entry.ResourceID),
 	)
 
+	// Wake any watchers blocked waiting for new entries.
+	w.notifyMu.Lock()
+	w.notifyVersion++
+	w.notifyCond.Broadcast()
+	w.notifyMu.Unlock()
+
 	return nil
 }
 
@@ -232,6 +345,16 @@ func (w *BadgerWAL) Read(ctx context.Context, afterSequence uint64, limit int) (
 		limit = defaultReadBatchSize
 	}
 
+	if w.mode == WALModeWriteBehind {
+		return w.readMerged(ctx, afterSequence, limit)
+	}
+
+	return w.readInOrder(ctx, afterSequence, limit)
+}
+
+// readInOrder returns entries from the in-order wal: keyspace after the
+// given sequence number.
+func (w *BadgerWAL) readInOrder(ctx context.Context, afterSequence uint64, limit int) ([]*WALEntry, error) {
 	var entries []*WALEntry
 
 	err := w.db.View(func(txn *badger.Txn) error {
@@ -436,16 +559,45 @@ func (w *BadgerWAL) Position(ctx context.Context) (*WALPosition, error) {
 	return &pos, nil
 }
 
-// Truncate removes entries before the given sequence number.
+// TruncateOptions configures how Truncate behaves with respect to watchers
+// that have not yet acknowledged the entries being removed.
+type TruncateOptions struct {
+	// Force truncates past the oldest unacknowledged consumer position.
+	// Without it, Truncate refuses to remove entries that a registered
+	// consumer (see NewWatcher) has not yet Ack'd.
+	Force bool
+}
+
+// Truncate removes entries before the given sequence number. It refuses to
+// remove entries that a registered consumer has not yet acknowledged; use
+// TruncateWithOptions with Force to override.
 func (w *BadgerWAL) Truncate(ctx context.Context, beforeSequence uint64) error {
+	return w.TruncateWithOptions(ctx, beforeSequence, TruncateOptions{})
+}
+
+// TruncateWithOptions removes entries before the given sequence number,
+// honoring TruncateOptions.Force as described on Truncate.
+func (w *BadgerWAL) TruncateWithOptions(ctx context.Context, beforeSequence uint64, opts TruncateOptions) error {
 	if w.closed.Load() {
 		return ErrWALClosed
 	}
 
+	if !opts.Force {
+		minPos, found, err := w.minConsumerPosition(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check consumer positions: %w", err)
+		}
+		if found && beforeSequence > minPos {
+			return fmt.Errorf("%w: min consumer position is %d", ErrTruncateBlockedByConsumer, minPos)
+		}
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	var keysToDelete [][]byte
+	var deletedEntries int64
+	var deletedBytes int64
 
 	// Collect keys to delete
 	err := w.db.View(func(txn *badger.Txn) error {
@@ -479,6 +631,10 @@ func (w *BadgerWAL) Truncate(ctx context.Context, beforeSequence uint64) error {
 			})
 			if err == nil && entryID != "" {
 				entryKey := []byte(fmt.Sprintf("%s%s", walEntryPrefix, entryID))
+				if entryItem, err := txn.Get(entryKey); err == nil {
+					deletedBytes += int64(entryItem.ValueSize())
+					deletedEntries++
+				}
 				keysToDelete = append(keysToDelete, entryKey)
 			}
 		}
@@ -508,6 +664,24 @@ func (w *BadgerWAL) Truncate(ctx context.Context, beforeSequence uint64) error {
 		return fmt.Errorf("failed to flush truncation batch: %w", err)
 	}
 
+	newEntryCount := w.entryCount.Load() - deletedEntries
+	newTotalBytes := w.totalBytes.Load() - deletedBytes
+
+	err = w.db.Update(func(txn *badger.Txn) error {
+		return w.saveMeta(txn, &walMeta{
+			CurrentSequence: w.sequence.Load(),
+			EntryCount:      newEntryCount,
+			TotalBytes:      newTotalBytes,
+			LastChecksum:    w.lastChecksum.Load(),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist metadata after truncation: %w", err)
+	}
+
+	w.entryCount.Store(newEntryCount)
+	w.totalBytes.Store(newTotalBytes)
+
 	w.logger.Info("truncated WAL",
 		zap.Uint64("before_sequence", beforeSequence),
 		zap.Int("entries_deleted", len(keysToDelete)/2),
@@ -531,12 +705,48 @@ func (w *BadgerWAL) Close() error {
 		return nil // Already closed
 	}
 
+	w.closeWatchers()
+
+	if w.flusherStop != nil {
+		close(w.flusherStop)
+		<-w.flusherDone
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	return w.db.Close()
 }
 
+// closeWatchers stops every live Watcher so none is left blocked in
+// notifyCond.Wait() after the WAL they tail is closed.
+func (w *BadgerWAL) closeWatchers() {
+	w.watcherMu.Lock()
+	watchers := make([]*Watcher, 0, len(w.watchers))
+	for wt := range w.watchers {
+		watchers = append(watchers, wt)
+	}
+	w.watcherMu.Unlock()
+
+	for _, wt := range watchers {
+		wt.Close()
+	}
+}
+
+// registerWatcher tracks wt so Close can stop it later.
+func (w *BadgerWAL) registerWatcher(wt *Watcher) {
+	w.watcherMu.Lock()
+	w.watchers[wt] = struct{}{}
+	w.watcherMu.Unlock()
+}
+
+// unregisterWatcher stops tracking wt, called once its run loop exits.
+func (w *BadgerWAL) unregisterWatcher(wt *Watcher) {
+	w.watcherMu.Lock()
+	delete(w.watchers, wt)
+	w.watcherMu.Unlock()
+}
+
 // Stats returns statistics about the WAL.
 func (w *BadgerWAL) Stats(ctx context.Context) (*WALStats, error) {
 	if w.closed.Load() {
@@ -593,6 +803,14 @@ func (w *BadgerWAL) Stats(ctx context.Context) (*WALStats, error) {
 	}, nil
 }
 
+// QuickStats returns the WAL's entry count and total byte size from
+// counters maintained incrementally on Append and Truncate, without
+// scanning the keyspace. Unlike Stats, it is cheap enough to call on
+// every Append (e.g. for quota enforcement).
+func (w *BadgerWAL) QuickStats() (entryCount int64, totalBytes int64) {
+	return w.entryCount.Load(), w.totalBytes.Load()
+}
+
 // WALStats provides statistics about the WAL.
 type WALStats struct {
 	EntryCount     int64     `json:"entry_count"`
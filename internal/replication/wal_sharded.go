@@ -0,0 +1,471 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/ar4mirez/maia/internal/metrics"
+)
+
+// defaultShardWeight is the fair-scheduling weight applied to a tenant that
+// has no entry in ShardedWALOptions.ShardWeights.
+const defaultShardWeight = 1
+
+// TenantQuota bounds how much of a ShardedWAL a single tenant may consume.
+// A zero value means unlimited.
+type TenantQuota struct {
+	// MaxBytes is the maximum total size, in bytes, of the tenant's shard.
+	MaxBytes int64
+
+	// MaxEntries is the maximum number of entries in the tenant's shard.
+	MaxEntries int64
+}
+
+// ShardedWALOptions configures a ShardedWAL.
+type ShardedWALOptions struct {
+	// BaseDir is the parent directory under which each tenant gets its own
+	// sub-log directory.
+	BaseDir string
+
+	// Region is the region identifier applied to every shard.
+	Region string
+
+	// Logger is the logger to use.
+	Logger *zap.Logger
+
+	// SyncWrites, ValueLogFileSize, Mode, and FlushInterval configure every
+	// tenant's underlying BadgerWAL identically; see BadgerWALOptions.
+	SyncWrites       bool
+	ValueLogFileSize int64
+	Mode             WALMode
+	FlushInterval    time.Duration
+
+	// Metrics, if set, is shared by every tenant's shard.
+	Metrics *metrics.Metrics
+
+	// ShardWeights assigns a fair-scheduling weight per tenant for Read's
+	// weighted round-robin merge. Tenants without an entry get
+	// defaultShardWeight.
+	ShardWeights map[string]int
+
+	// Quotas bounds each tenant's shard; Append returns ErrQuotaExceeded
+	// once a tenant's quota is reached. Tenants without an entry are
+	// unlimited.
+	Quotas map[string]TenantQuota
+}
+
+// ShardedWALCursor is an opaque position in a ShardedWAL's merged read
+// stream, tracking the last sequence number consumed per tenant. A nil or
+// zero-value ShardedWALCursor reads from the beginning of every shard.
+type ShardedWALCursor map[string]uint64
+
+// CursorWAL is the ShardedWAL equivalent of the single-log WAL interface.
+// Its Read takes and returns a ShardedWALCursor rather than a bare
+// afterSequence: each tenant shard has its own independent sequence
+// counter, so one global afterSequence applied to every shard cannot
+// paginate correctly across tenants. ShardedWAL satisfies CursorWAL, not
+// WAL, and the two are not interchangeable.
+type CursorWAL interface {
+	Append(ctx context.Context, entry *WALEntry) error
+	Read(ctx context.Context, cursor ShardedWALCursor, limit int) ([]*WALEntry, ShardedWALCursor, error)
+	ReadByID(ctx context.Context, afterID string, limit int) ([]*WALEntry, error)
+	GetEntry(ctx context.Context, id string) (*WALEntry, error)
+	Position(ctx context.Context) (*WALPosition, error)
+	Truncate(ctx context.Context, beforeSequence uint64) error
+	Sync(ctx context.Context) error
+	Close() error
+}
+
+// ShardedWAL shards a WAL by TenantID into independent sub-logs, each with
+// its own sequence counter, isolating noisy tenants from one another. It
+// satisfies CursorWAL rather than WAL: see CursorWAL for why Read's
+// signature differs from the single-log WAL interface.
+type ShardedWAL struct {
+	opts ShardedWALOptions
+
+	mu     sync.RWMutex
+	shards map[string]*BadgerWAL
+	closed atomic.Bool
+
+	region string
+}
+
+// NewShardedWAL creates a ShardedWAL. Per-tenant sub-logs are created
+// lazily on first use.
+func NewShardedWAL(opts *ShardedWALOptions) (*ShardedWAL, error) {
+	if opts.BaseDir == "" {
+		return nil, errors.New("base directory is required")
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	o := *opts
+	o.Logger = logger
+
+	return &ShardedWAL{
+		opts:   o,
+		shards: make(map[string]*BadgerWAL),
+		region: opts.Region,
+	}, nil
+}
+
+// shardFor returns the tenant's sub-log, creating it on first use.
+func (s *ShardedWAL) shardFor(tenantID string) (*BadgerWAL, error) {
+	s.mu.RLock()
+	shard, ok := s.shards[tenantID]
+	s.mu.RUnlock()
+	if ok {
+		return shard, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if shard, ok := s.shards[tenantID]; ok {
+		return shard, nil
+	}
+
+	shard, err := NewBadgerWAL(&BadgerWALOptions{
+		DataDir:          filepath.Join(s.opts.BaseDir, tenantID),
+		Region:           s.opts.Region,
+		Logger:           s.opts.Logger,
+		SyncWrites:       s.opts.SyncWrites,
+		ValueLogFileSize: s.opts.ValueLogFileSize,
+		Mode:             s.opts.Mode,
+		FlushInterval:    s.opts.FlushInterval,
+		Metrics:          s.opts.Metrics,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shard for tenant %q: %w", tenantID, err)
+	}
+
+	s.shards[tenantID] = shard
+	return shard, nil
+}
+
+// weightFor returns the fair-scheduling weight configured for a tenant.
+func (s *ShardedWAL) weightFor(tenantID string) int {
+	if w, ok := s.opts.ShardWeights[tenantID]; ok && w > 0 {
+		return w
+	}
+	return defaultShardWeight
+}
+
+// checkQuota returns ErrQuotaExceeded if appending entrySize bytes would
+// push the tenant's shard past its configured quota. It reads the shard's
+// incrementally-maintained counters rather than scanning its keyspace, so
+// it stays cheap to call on every Append regardless of shard history.
+func (s *ShardedWAL) checkQuota(tenantID string, shard *BadgerWAL, entrySize int64) error {
+	quota, ok := s.opts.Quotas[tenantID]
+	if !ok || (quota.MaxBytes <= 0 && quota.MaxEntries <= 0) {
+		return nil
+	}
+
+	entryCount, totalBytes := shard.QuickStats()
+
+	if quota.MaxEntries > 0 && entryCount+1 > quota.MaxEntries {
+		return fmt.Errorf("%w: tenant %q would exceed max entries %d", ErrQuotaExceeded, tenantID, quota.MaxEntries)
+	}
+	if quota.MaxBytes > 0 && totalBytes+entrySize > quota.MaxBytes {
+		return fmt.Errorf("%w: tenant %q would exceed max bytes %d", ErrQuotaExceeded, tenantID, quota.MaxBytes)
+	}
+	return nil
+}
+
+// Append routes entry to its tenant's shard, rejecting it with
+// ErrQuotaExceeded if the tenant has exceeded its TenantQuota.
+func (s *ShardedWAL) Append(ctx context.Context, entry *WALEntry) error {
+	if s.closed.Load() {
+		return ErrWALClosed
+	}
+	if entry.TenantID == "" {
+		return errors.New("sharded WAL append requires a tenant ID")
+	}
+
+	shard, err := s.shardFor(entry.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkQuota(entry.TenantID, shard, int64(len(entry.Data)+len(entry.PreviousData))); err != nil {
+		return err
+	}
+
+	return shard.Append(ctx, entry)
+}
+
+// Read returns a weighted round-robin merge of each tenant shard's entries
+// after each tenant's respective cursor position, fairly interleaving
+// tenants per ShardWeights so that no single tenant can dominate the
+// result. It returns the cursor to pass to the next call so pagination can
+// resume without skipping or re-reading entries; cursor may be nil to read
+// from the beginning of every shard.
+//
+// Each tenant shard has its own independent sequence counter, so there is
+// no single uint64 that means "resume after this point" across tenants
+// with different entry counts — unlike BadgerWAL.Read, Read takes a
+// per-tenant cursor rather than a bare sequence number.
+func (s *ShardedWAL) Read(ctx context.Context, cursor ShardedWALCursor, limit int) ([]*WALEntry, ShardedWALCursor, error) {
+	if s.closed.Load() {
+		return nil, nil, ErrWALClosed
+	}
+	if limit <= 0 {
+		limit = defaultReadBatchSize
+	}
+
+	tenants, shards := s.snapshotShards()
+
+	queues := make(map[string][]*WALEntry, len(tenants))
+	for _, tenantID := range tenants {
+		batch, err := shards[tenantID].Read(ctx, cursor[tenantID], limit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read tenant %q shard: %w", tenantID, err)
+		}
+		queues[tenantID] = batch
+	}
+
+	next := make(ShardedWALCursor, len(cursor))
+	for tenantID, seq := range cursor {
+		next[tenantID] = seq
+	}
+
+	merged := make([]*WALEntry, 0, limit)
+	for len(merged) < limit {
+		progressed := false
+		for _, tenantID := range tenants {
+			weight := s.weightFor(tenantID)
+			for i := 0; i < weight && len(queues[tenantID]) > 0 && len(merged) < limit; i++ {
+				entry := queues[tenantID][0]
+				merged = append(merged, entry)
+				queues[tenantID] = queues[tenantID][1:]
+				next[tenantID] = entry.Sequence
+				progressed = true
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return merged, next, nil
+}
+
+// ReadByID returns entries after the entry identified by afterID, searching
+// every shard for it. If afterID is empty or not found, it reads from the
+// beginning of every shard.
+func (s *ShardedWAL) ReadByID(ctx context.Context, afterID string, limit int) ([]*WALEntry, error) {
+	if s.closed.Load() {
+		return nil, ErrWALClosed
+	}
+
+	if afterID == "" {
+		entries, _, err := s.Read(ctx, nil, limit)
+		return entries, err
+	}
+
+	entry, err := s.GetEntry(ctx, afterID)
+	if err != nil {
+		entries, _, readErr := s.Read(ctx, nil, limit)
+		return entries, readErr
+	}
+
+	tenants, shards := s.snapshotShards()
+	queues := make(map[string][]*WALEntry, len(tenants))
+	for _, tenantID := range tenants {
+		after := uint64(0)
+		if tenantID == entry.TenantID {
+			after = entry.Sequence
+		}
+		batch, err := shards[tenantID].Read(ctx, after, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tenant %q shard: %w", tenantID, err)
+		}
+		queues[tenantID] = batch
+	}
+
+	merged := make([]*WALEntry, 0, limit)
+	for len(merged) < limit {
+		progressed := false
+		for _, tenantID := range tenants {
+			weight := s.weightFor(tenantID)
+			for i := 0; i < weight && len(queues[tenantID]) > 0 && len(merged) < limit; i++ {
+				merged = append(merged, queues[tenantID][0])
+				queues[tenantID] = queues[tenantID][1:]
+				progressed = true
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return merged, nil
+}
+
+// GetEntry searches every tenant shard for the entry identified by id.
+func (s *ShardedWAL) GetEntry(ctx context.Context, id string) (*WALEntry, error) {
+	if s.closed.Load() {
+		return nil, ErrWALClosed
+	}
+
+	_, shards := s.snapshotShards()
+	for _, shard := range shards {
+		entry, err := shard.GetEntry(ctx, id)
+		if err == nil {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("WAL entry not found: %s", id)
+}
+
+// Position returns the position of the most recently appended entry across
+// all tenant shards.
+func (s *ShardedWAL) Position(ctx context.Context) (*WALPosition, error) {
+	if s.closed.Load() {
+		return nil, ErrWALClosed
+	}
+
+	_, shards := s.snapshotShards()
+
+	var latest *WALPosition
+	for _, shard := range shards {
+		pos, err := shard.Position(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil || pos.Timestamp.After(latest.Timestamp) {
+			latest = pos
+		}
+	}
+	if latest == nil {
+		return &WALPosition{Timestamp: time.Now().UTC()}, nil
+	}
+	return latest, nil
+}
+
+// Truncate applies beforeSequence to every tenant shard. Use
+// TruncatePerTenant to truncate shards to different sequences.
+func (s *ShardedWAL) Truncate(ctx context.Context, beforeSequence uint64) error {
+	if s.closed.Load() {
+		return ErrWALClosed
+	}
+
+	_, shards := s.snapshotShards()
+	for tenantID, shard := range shards {
+		if err := shard.Truncate(ctx, beforeSequence); err != nil {
+			return fmt.Errorf("failed to truncate tenant %q shard: %w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// TruncatePerTenant truncates each named tenant's shard to its own cutoff
+// sequence. Tenants without a shard yet are ignored.
+func (s *ShardedWAL) TruncatePerTenant(ctx context.Context, cutoffs map[string]uint64) error {
+	if s.closed.Load() {
+		return ErrWALClosed
+	}
+
+	for tenantID, beforeSequence := range cutoffs {
+		s.mu.RLock()
+		shard, ok := s.shards[tenantID]
+		s.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := shard.Truncate(ctx, beforeSequence); err != nil {
+			return fmt.Errorf("failed to truncate tenant %q shard: %w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// Sync fsyncs every tenant shard.
+func (s *ShardedWAL) Sync(ctx context.Context) error {
+	if s.closed.Load() {
+		return ErrWALClosed
+	}
+
+	_, shards := s.snapshotShards()
+	for tenantID, shard := range shards {
+		if err := shard.Sync(ctx); err != nil {
+			return fmt.Errorf("failed to sync tenant %q shard: %w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// Close closes every tenant shard.
+func (s *ShardedWAL) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+
+	_, shards := s.snapshotShards()
+	var firstErr error
+	for tenantID, shard := range shards {
+		if err := shard.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close tenant %q shard: %w", tenantID, err)
+		}
+	}
+	return firstErr
+}
+
+// ShardedWALStats reports aggregate and per-tenant WAL statistics.
+type ShardedWALStats struct {
+	// PerTenant breaks stats down by tenant ID.
+	PerTenant map[string]*WALStats `json:"per_tenant"`
+
+	// EntryCount and TotalBytes are summed across all tenants.
+	EntryCount int64 `json:"entry_count"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// Stats returns aggregate and per-tenant statistics across all shards.
+func (s *ShardedWAL) Stats(ctx context.Context) (*ShardedWALStats, error) {
+	if s.closed.Load() {
+		return nil, ErrWALClosed
+	}
+
+	tenants, shards := s.snapshotShards()
+
+	out := &ShardedWALStats{PerTenant: make(map[string]*WALStats, len(tenants))}
+	for _, tenantID := range tenants {
+		stats, err := shards[tenantID].Stats(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats for tenant %q: %w", tenantID, err)
+		}
+		out.PerTenant[tenantID] = stats
+		out.EntryCount += stats.EntryCount
+		out.TotalBytes += stats.TotalBytes
+	}
+
+	return out, nil
+}
+
+// snapshotShards returns a sorted tenant ID list and the shard map as they
+// stood at call time, for consistent iteration without holding the lock.
+func (s *ShardedWAL) snapshotShards() ([]string, map[string]*BadgerWAL) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shards := make(map[string]*BadgerWAL, len(s.shards))
+	tenants := make([]string, 0, len(s.shards))
+	for tenantID, shard := range s.shards {
+		shards[tenantID] = shard
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+
+	return tenants, shards
+}
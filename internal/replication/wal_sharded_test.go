@@ -0,0 +1,238 @@
+package replication
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupTestShardedWAL(t *testing.T, opts *ShardedWALOptions) (*ShardedWAL, func()) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "sharded-wal-test-*")
+	require.NoError(t, err)
+
+	if opts == nil {
+		opts = &ShardedWALOptions{}
+	}
+	opts.BaseDir = dir
+	opts.Region = "test-region"
+	opts.Logger = zap.NewNop()
+
+	sw, err := NewShardedWAL(opts)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		sw.Close()
+		os.RemoveAll(dir)
+	}
+
+	return sw, cleanup
+}
+
+func TestShardedWAL_AppendIsolatesTenantSequences(t *testing.T) {
+	sw, cleanup := setupTestShardedWAL(t, nil)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-a",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-a",
+		}))
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-b",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-b",
+		}))
+	}
+
+	stats, err := sw.Stats(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.PerTenant["tenant-a"].EntryCount, int64(3))
+	assert.GreaterOrEqual(t, stats.PerTenant["tenant-b"].EntryCount, int64(2))
+	assert.Equal(t, uint64(3), stats.PerTenant["tenant-a"].CurrentSeq)
+	assert.Equal(t, uint64(2), stats.PerTenant["tenant-b"].CurrentSeq)
+
+	shardA, err := sw.shardFor("tenant-a")
+	require.NoError(t, err)
+	entryCount, _ := shardA.QuickStats()
+	assert.Equal(t, int64(3), entryCount)
+}
+
+func TestShardedWAL_ReadWeightedRoundRobin(t *testing.T) {
+	sw, cleanup := setupTestShardedWAL(t, &ShardedWALOptions{
+		ShardWeights: map[string]int{"tenant-a": 2, "tenant-b": 1},
+	})
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-a",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-a",
+		}))
+	}
+	for i := 0; i < 4; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-b",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-b",
+		}))
+	}
+
+	entries, _, err := sw.Read(ctx, nil, 6)
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+
+	// Weight 2:1 means the first round takes 2 from tenant-a then 1 from
+	// tenant-b, repeating.
+	want := []string{"tenant-a", "tenant-a", "tenant-b", "tenant-a", "tenant-a", "tenant-b"}
+	got := make([]string, len(entries))
+	for i, e := range entries {
+		got[i] = e.TenantID
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestShardedWAL_QuotaExceeded(t *testing.T) {
+	sw, cleanup := setupTestShardedWAL(t, &ShardedWALOptions{
+		Quotas: map[string]TenantQuota{
+			"tenant-a": {MaxEntries: 2},
+		},
+	})
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-a",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-a",
+		}))
+	}
+
+	err := sw.Append(ctx, &WALEntry{
+		TenantID:     "tenant-a",
+		Operation:    OperationCreate,
+		ResourceType: ResourceTypeMemory,
+		ResourceID:   "mem-a",
+	})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// A different tenant is unaffected by tenant-a's quota.
+	err = sw.Append(ctx, &WALEntry{
+		TenantID:     "tenant-b",
+		Operation:    OperationCreate,
+		ResourceType: ResourceTypeMemory,
+		ResourceID:   "mem-b",
+	})
+	assert.NoError(t, err)
+}
+
+func TestShardedWAL_ReadPaginatesWithoutSkippingAcrossTenants(t *testing.T) {
+	sw, cleanup := setupTestShardedWAL(t, nil)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	require.NoError(t, sw.Append(ctx, &WALEntry{
+		TenantID:     "tenant-a",
+		Operation:    OperationCreate,
+		ResourceType: ResourceTypeMemory,
+		ResourceID:   "mem-a",
+	}))
+	require.NoError(t, sw.Append(ctx, &WALEntry{
+		TenantID:     "tenant-a",
+		Operation:    OperationCreate,
+		ResourceType: ResourceTypeMemory,
+		ResourceID:   "mem-a",
+	}))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-b",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-b",
+		}))
+	}
+
+	var all []*WALEntry
+	var cursor ShardedWALCursor
+	for {
+		batch, next, err := sw.Read(ctx, cursor, 3)
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			break
+		}
+		all = append(all, batch...)
+		cursor = next
+	}
+
+	require.Len(t, all, 7)
+
+	var bSeqs []uint64
+	for _, e := range all {
+		if e.TenantID == "tenant-b" {
+			bSeqs = append(bSeqs, e.Sequence)
+		}
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, bSeqs)
+}
+
+func TestShardedWAL_TruncatePerTenant(t *testing.T) {
+	sw, cleanup := setupTestShardedWAL(t, nil)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-a",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-a",
+		}))
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sw.Append(ctx, &WALEntry{
+			TenantID:     "tenant-b",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-b",
+		}))
+	}
+
+	require.NoError(t, sw.TruncatePerTenant(ctx, map[string]uint64{"tenant-a": 4}))
+
+	entries, _, err := sw.Read(ctx, nil, 20)
+	require.NoError(t, err)
+
+	var aCount, bCount int
+	for _, e := range entries {
+		switch e.TenantID {
+		case "tenant-a":
+			aCount++
+		case "tenant-b":
+			bCount++
+		}
+	}
+	assert.Equal(t, 2, aCount) // entries 4-5 remain
+	assert.Equal(t, 5, bCount)
+}
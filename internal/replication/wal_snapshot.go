@@ -0,0 +1,379 @@
+package replication
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+// SnapshotMeta describes a point-in-time snapshot of a BadgerWAL.
+type SnapshotMeta struct {
+	// Sequence is the WAL sequence at the time the snapshot was taken.
+	Sequence uint64 `json:"sequence"`
+
+	// ChecksumRoot is a rolling CRC32 over every entry's serialized bytes,
+	// letting RestoreFromSnapshot detect a truncated or corrupted stream.
+	ChecksumRoot uint64 `json:"checksum_root"`
+
+	// EntryCount is the number of entries included in the snapshot.
+	EntryCount int64 `json:"entry_count"`
+
+	// Region is the region the snapshot was taken from.
+	Region string `json:"region"`
+
+	// CreatedAt is when the snapshot was taken.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RestoreOptions configures RestoreFromSnapshot.
+type RestoreOptions struct {
+	// VerifyChecksums rejects the restore if any entry's own checksum, the
+	// inter-entry checksum chain, or the snapshot's checksum root fails to
+	// verify.
+	VerifyChecksums bool
+}
+
+// Snapshot serializes the current sequence, checksum-chain root, and every
+// wal: entry reachable through the walseq: sequence index (in sequence
+// order) to dst. The result is a self-contained stream that
+// RestoreFromSnapshot can replay into a fresh BadgerWAL. Entries written by
+// AppendOutOfOrder are indexed only under wal-oo: and are not included.
+func (w *BadgerWAL) Snapshot(ctx context.Context, dst io.Writer) (SnapshotMeta, error) {
+	if w.closed.Load() {
+		return SnapshotMeta{}, ErrWALClosed
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var entries []*WALEntry
+
+	err := w.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(walSequencePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			var entryID string
+			if err := it.Item().Value(func(val []byte) error {
+				entryID = string(val)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			item, err := txn.Get([]byte(walEntryPrefix + entryID))
+			if err != nil {
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue
+				}
+				return err
+			}
+
+			var entry WALEntry
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			}); err != nil {
+				return err
+			}
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return SnapshotMeta{}, fmt.Errorf("failed to read WAL for snapshot: %w", err)
+	}
+
+	meta := SnapshotMeta{
+		Sequence:   w.sequence.Load(),
+		EntryCount: int64(len(entries)),
+		Region:     w.region,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	var checksumRoot uint32
+	encoded := make([][]byte, len(entries))
+	for i, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return SnapshotMeta{}, fmt.Errorf("failed to marshal WAL entry: %w", err)
+		}
+		checksumRoot = crc32.Update(checksumRoot, crc32.IEEETable, data)
+		encoded[i] = data
+	}
+	meta.ChecksumRoot = uint64(checksumRoot)
+
+	if err := writeSnapshotRecord(dst, &meta); err != nil {
+		return SnapshotMeta{}, fmt.Errorf("failed to write snapshot metadata: %w", err)
+	}
+	for _, data := range encoded {
+		if err := writeSnapshotRecordBytes(dst, data); err != nil {
+			return SnapshotMeta{}, fmt.Errorf("failed to write snapshot entry: %w", err)
+		}
+	}
+
+	w.logger.Info("wrote WAL snapshot",
+		zap.Uint64("sequence", meta.Sequence),
+		zap.Int64("entry_count", meta.EntryCount),
+	)
+
+	return meta, nil
+}
+
+// RestoreFromSnapshot replays a snapshot produced by Snapshot into w, which
+// must be a fresh (empty) WAL. When opts.VerifyChecksums is set, it
+// rejects the restore on any checksum, chain, or checksum-root mismatch.
+func (w *BadgerWAL) RestoreFromSnapshot(ctx context.Context, r io.Reader, opts RestoreOptions) error {
+	if w.closed.Load() {
+		return ErrWALClosed
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var meta SnapshotMeta
+	if err := readSnapshotRecord(r, &meta); err != nil {
+		return fmt.Errorf("failed to read snapshot metadata: %w", err)
+	}
+
+	wb := w.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	var checksumRoot uint32
+	var prevChecksum uint64
+	var count int64
+	var totalBytes int64
+	var lastSeq uint64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		data, err := readSnapshotRecordBytes(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot entry: %w", err)
+		}
+
+		var entry WALEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("failed to unmarshal snapshot entry: %w", err)
+		}
+
+		if opts.VerifyChecksums {
+			if !entry.VerifyChecksum() {
+				return fmt.Errorf("%w: entry %s sequence %d", ErrChecksumMismatch, entry.ID, entry.Sequence)
+			}
+			if entry.PrevChecksum != prevChecksum {
+				return fmt.Errorf("%w: entry %s sequence %d", ErrChecksumChainBroken, entry.ID, entry.Sequence)
+			}
+		}
+		prevChecksum = uint64(entry.Checksum)
+		checksumRoot = crc32.Update(checksumRoot, crc32.IEEETable, data)
+		count++
+		totalBytes += int64(len(data))
+		lastSeq = entry.Sequence
+
+		if err := wb.Set([]byte(walEntryPrefix+entry.ID), data); err != nil {
+			return fmt.Errorf("failed to stage snapshot entry: %w", err)
+		}
+		seqKey := []byte(fmt.Sprintf("%s%020d", walSequencePrefix, entry.Sequence))
+		if err := wb.Set(seqKey, []byte(entry.ID)); err != nil {
+			return fmt.Errorf("failed to stage snapshot sequence index: %w", err)
+		}
+	}
+
+	if opts.VerifyChecksums && uint64(checksumRoot) != meta.ChecksumRoot {
+		return fmt.Errorf("%w: expected %d got %d", ErrSnapshotChecksumMismatch, meta.ChecksumRoot, checksumRoot)
+	}
+
+	if err := wb.Flush(); err != nil {
+		return fmt.Errorf("failed to restore snapshot entries: %w", err)
+	}
+
+	err := w.db.Update(func(txn *badger.Txn) error {
+		return w.saveMeta(txn, &walMeta{
+			CurrentSequence: lastSeq,
+			LastChecksum:    uint32(prevChecksum),
+			EntryCount:      count,
+			TotalBytes:      totalBytes,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save restored WAL metadata: %w", err)
+	}
+
+	w.sequence.Store(lastSeq)
+	w.lastChecksum.Store(uint32(prevChecksum))
+	w.entryCount.Store(count)
+	w.totalBytes.Store(totalBytes)
+
+	w.logger.Info("restored WAL from snapshot",
+		zap.Uint64("snapshot_sequence", meta.Sequence),
+		zap.Int64("entries_restored", count),
+	)
+
+	return nil
+}
+
+// Applier processes a single WAL entry during ReplayUntil.
+type Applier func(ctx context.Context, entry *WALEntry) error
+
+// ReplayTargetKind selects which field of a ReplayTarget is used to stop
+// replay.
+type ReplayTargetKind int
+
+const (
+	// ReplayTargetSequence stops after the entry at Sequence is applied.
+	ReplayTargetSequence ReplayTargetKind = iota
+
+	// ReplayTargetTimestamp applies entries up to and including
+	// Timestamp, stopping before the first entry strictly past it.
+	ReplayTargetTimestamp
+
+	// ReplayTargetEntryID stops after the entry with EntryID is applied.
+	ReplayTargetEntryID
+)
+
+// ReplayTarget identifies the point at which ReplayUntil stops.
+type ReplayTarget struct {
+	Kind      ReplayTargetKind
+	Sequence  uint64
+	Timestamp time.Time
+	EntryID   string
+}
+
+// ReplayUntil reads WAL entries in order from the beginning, invoking apply
+// for each one and stopping once target is reached (inclusive). It returns
+// nil if the target is never reached before the log is exhausted.
+func (w *BadgerWAL) ReplayUntil(ctx context.Context, target ReplayTarget, apply Applier) error {
+	if w.closed.Load() {
+		return ErrWALClosed
+	}
+	if apply == nil {
+		return errors.New("replay requires an Applier")
+	}
+
+	afterSeq := uint64(0)
+	for {
+		batch, err := w.Read(ctx, afterSeq, defaultReadBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read WAL for replay: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for _, entry := range batch {
+			afterSeq = entry.Sequence
+
+			if replayPastTarget(target, entry) {
+				return nil
+			}
+
+			if err := apply(ctx, entry); err != nil {
+				return fmt.Errorf("applier failed at sequence %d: %w", entry.Sequence, err)
+			}
+
+			if replayAtTarget(target, entry) {
+				return nil
+			}
+		}
+	}
+}
+
+// replayPastTarget reports whether entry lies strictly beyond target,
+// meaning it must not be applied.
+func replayPastTarget(target ReplayTarget, entry *WALEntry) bool {
+	switch target.Kind {
+	case ReplayTargetSequence:
+		return entry.Sequence > target.Sequence
+	case ReplayTargetTimestamp:
+		return entry.Timestamp.After(target.Timestamp)
+	default:
+		return false
+	}
+}
+
+// replayAtTarget reports whether entry is the last one ReplayUntil should apply.
+func replayAtTarget(target ReplayTarget, entry *WALEntry) bool {
+	switch target.Kind {
+	case ReplayTargetSequence:
+		return entry.Sequence >= target.Sequence
+	case ReplayTargetTimestamp:
+		return !entry.Timestamp.Before(target.Timestamp)
+	case ReplayTargetEntryID:
+		return entry.ID == target.EntryID
+	default:
+		return false
+	}
+}
+
+// writeSnapshotRecord marshals v as JSON and writes it as a length-prefixed record.
+func writeSnapshotRecord(dst io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeSnapshotRecordBytes(dst, data)
+}
+
+// writeSnapshotRecordBytes writes data as a 4-byte big-endian length prefix
+// followed by the bytes themselves.
+func writeSnapshotRecordBytes(dst io.Writer, data []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+	if _, err := dst.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := dst.Write(data)
+	return err
+}
+
+// readSnapshotRecord reads a length-prefixed record and unmarshals it into v.
+func readSnapshotRecord(r io.Reader, v interface{}) error {
+	data, err := readSnapshotRecordBytes(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readSnapshotRecordBytes reads a 4-byte big-endian length prefix followed
+// by that many bytes. It returns io.EOF only if the prefix itself is
+// missing (a clean end of stream).
+func readSnapshotRecordBytes(r io.Reader) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated snapshot record length: %w", err)
+		}
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(lenBuf[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated snapshot record body: %w", err)
+	}
+	return data, nil
+}
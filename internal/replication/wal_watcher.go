@@ -0,0 +1,343 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultWatchBufferSize is the default capacity of a Watcher's entry
+	// channel when WatcherOptions.BufferSize is not set.
+	defaultWatchBufferSize = 256
+
+	// watchReadBatchSize bounds how many entries a Watcher pulls from the
+	// WAL per poll once it has been woken up.
+	watchReadBatchSize = 100
+)
+
+// WatchMode controls how a Watcher behaves when its entry channel is full.
+type WatchMode int
+
+const (
+	// WatchModeBlock blocks Append delivery to this watcher until the
+	// consumer drains the channel. Guarantees no entry is skipped.
+	WatchModeBlock WatchMode = iota
+
+	// WatchModeDropOldest drops the oldest buffered entry to make room for
+	// the newest one, trading completeness for a bounded memory footprint.
+	WatchModeDropOldest
+)
+
+// WatcherOptions configures a Watcher created via BadgerWAL.NewWatcher.
+type WatcherOptions struct {
+	// ConsumerID identifies this consumer's checkpoint in the
+	// wal-consumers: keyspace. If set, the watcher resumes from the last
+	// acknowledged sequence on restart instead of FromSequence.
+	ConsumerID string
+
+	// FromSequence is the sequence to start tailing after. Ignored if
+	// ConsumerID is set and already has a persisted checkpoint.
+	FromSequence uint64
+
+	// BufferSize is the capacity of the entry channel. Defaults to
+	// defaultWatchBufferSize.
+	BufferSize int
+
+	// Mode controls backpressure behavior when the channel is full.
+	Mode WatchMode
+}
+
+// Watcher live-tails a BadgerWAL, modeled on Prometheus's tsdb/wlog.Watcher.
+// It is woken by the WAL's notifyCond whenever Append adds a new entry,
+// coalescing what would otherwise be one wake-up per append.
+type Watcher struct {
+	wal        *BadgerWAL
+	consumerID string
+	mode       WatchMode
+
+	entries chan *WALEntry
+	errs    chan error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lastSeq atomic.Uint64
+	closed  atomic.Bool
+}
+
+// consumerPosition is the persisted checkpoint for a watcher consumer.
+type consumerPosition struct {
+	Sequence  uint64    `json:"sequence"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Watch starts tailing the WAL for entries after fromSeq and returns
+// channels for new entries and any fatal error encountered. The returned
+// watcher has no persisted consumer checkpoint; callers that need
+// restart-safe resumption should use NewWatcher with a ConsumerID.
+func (w *BadgerWAL) Watch(ctx context.Context, fromSeq uint64) (<-chan *WALEntry, <-chan error) {
+	wt, err := w.NewWatcher(ctx, WatcherOptions{FromSequence: fromSeq})
+	if err != nil {
+		errs := make(chan error, 1)
+		errs <- err
+		close(errs)
+		entries := make(chan *WALEntry)
+		close(entries)
+		return entries, errs
+	}
+	return wt.entries, wt.errs
+}
+
+// NewWatcher creates a Watcher tailing the WAL from either opts.FromSequence
+// or, if opts.ConsumerID is set, the consumer's persisted checkpoint.
+func (w *BadgerWAL) NewWatcher(ctx context.Context, opts WatcherOptions) (*Watcher, error) {
+	if w.closed.Load() {
+		return nil, ErrWALClosed
+	}
+
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultWatchBufferSize
+	}
+
+	from := opts.FromSequence
+	if opts.ConsumerID != "" {
+		pos, found, err := w.loadConsumerPosition(ctx, opts.ConsumerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load consumer position: %w", err)
+		}
+		if found {
+			from = pos
+		} else if err := w.saveConsumerPosition(ctx, opts.ConsumerID, from); err != nil {
+			return nil, fmt.Errorf("failed to register consumer: %w", err)
+		}
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	wt := &Watcher{
+		wal:        w,
+		consumerID: opts.ConsumerID,
+		mode:       opts.Mode,
+		entries:    make(chan *WALEntry, bufferSize),
+		errs:       make(chan error, 1),
+		ctx:        wctx,
+		cancel:     cancel,
+	}
+	wt.lastSeq.Store(from)
+
+	w.registerWatcher(wt)
+	go wt.run()
+
+	return wt, nil
+}
+
+// Entries returns the channel of newly appended entries.
+func (wt *Watcher) Entries() <-chan *WALEntry {
+	return wt.entries
+}
+
+// Errors returns the channel that receives a fatal watcher error, if any.
+func (wt *Watcher) Errors() <-chan error {
+	return wt.errs
+}
+
+// Ack advances the consumer's persisted checkpoint to seq, allowing
+// Truncate to reclaim entries below it. It is a no-op for watchers
+// created without a ConsumerID.
+func (wt *Watcher) Ack(ctx context.Context, seq uint64) error {
+	if wt.consumerID == "" {
+		return nil
+	}
+	return wt.wal.saveConsumerPosition(ctx, wt.consumerID, seq)
+}
+
+// Close stops the watcher and releases its resources. It does not remove
+// the consumer's persisted checkpoint.
+func (wt *Watcher) Close() error {
+	if wt.closed.Swap(true) {
+		return nil
+	}
+	wt.cancel()
+
+	wt.wal.notifyMu.Lock()
+	wt.wal.notifyCond.Broadcast()
+	wt.wal.notifyMu.Unlock()
+
+	return nil
+}
+
+// run tails the WAL, delivering new entries to the entries channel until
+// the watcher is closed or its context is cancelled.
+func (wt *Watcher) run() {
+	defer close(wt.entries)
+	defer close(wt.errs)
+	defer wt.wal.unregisterWatcher(wt)
+
+	// Wake our own cond.Wait when the context is cancelled out from under us.
+	go func() {
+		<-wt.ctx.Done()
+		wt.wal.notifyMu.Lock()
+		wt.wal.notifyCond.Broadcast()
+		wt.wal.notifyMu.Unlock()
+	}()
+
+	for {
+		if wt.closed.Load() || wt.ctx.Err() != nil {
+			return
+		}
+
+		// Capture the notification version before Read so that a Broadcast
+		// landing after Read observed no new data, but before we reach
+		// Wait, still gets noticed: Wait only blocks below if the version
+		// is unchanged, rather than racing a check-then-wait against
+		// Append's Broadcast.
+		wt.wal.notifyMu.Lock()
+		seenVersion := wt.wal.notifyVersion
+		wt.wal.notifyMu.Unlock()
+
+		batch, err := wt.wal.Read(wt.ctx, wt.lastSeq.Load(), watchReadBatchSize)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) && !errors.Is(err, ErrWALClosed) {
+				select {
+				case wt.errs <- err:
+				default:
+				}
+			}
+			return
+		}
+
+		if len(batch) == 0 {
+			wt.wal.notifyMu.Lock()
+			for wt.wal.notifyVersion == seenVersion && !wt.closed.Load() && wt.ctx.Err() == nil {
+				wt.wal.notifyCond.Wait()
+			}
+			wt.wal.notifyMu.Unlock()
+			continue
+		}
+
+		for _, entry := range batch {
+			if !wt.deliver(entry) {
+				return
+			}
+			wt.lastSeq.Store(entry.Sequence)
+		}
+	}
+}
+
+// deliver sends entry on the entries channel according to wt.mode. It
+// returns false if the watcher was closed or cancelled while delivering.
+func (wt *Watcher) deliver(entry *WALEntry) bool {
+	switch wt.mode {
+	case WatchModeDropOldest:
+		for {
+			select {
+			case wt.entries <- entry:
+				return true
+			case <-wt.ctx.Done():
+				return false
+			default:
+				select {
+				case <-wt.entries:
+				default:
+				}
+			}
+		}
+	case WatchModeBlock:
+		fallthrough
+	default:
+		select {
+		case wt.entries <- entry:
+			return true
+		case <-wt.ctx.Done():
+			return false
+		}
+	}
+}
+
+// loadConsumerPosition returns the persisted checkpoint for consumerID.
+func (w *BadgerWAL) loadConsumerPosition(_ context.Context, consumerID string) (uint64, bool, error) {
+	var pos consumerPosition
+	found := false
+
+	err := w.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(walConsumerPrefix + consumerID))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &pos)
+		})
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return pos.Sequence, found, nil
+}
+
+// saveConsumerPosition persists seq as consumerID's checkpoint.
+func (w *BadgerWAL) saveConsumerPosition(_ context.Context, consumerID string, seq uint64) error {
+	data, err := json.Marshal(consumerPosition{Sequence: seq, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	err = w.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(walConsumerPrefix+consumerID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save consumer position: %w", err)
+	}
+
+	w.logger.Debug("advanced WAL consumer checkpoint",
+		zap.String("consumer_id", consumerID),
+		zap.Uint64("sequence", seq),
+	)
+
+	return nil
+}
+
+// minConsumerPosition returns the lowest persisted checkpoint across all
+// registered consumers, and whether any consumer is registered at all.
+func (w *BadgerWAL) minConsumerPosition(_ context.Context) (uint64, bool, error) {
+	var min uint64
+	found := false
+
+	err := w.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(walConsumerPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var pos consumerPosition
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &pos)
+			})
+			if err != nil {
+				return err
+			}
+			if !found || pos.Sequence < min {
+				min = pos.Sequence
+				found = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return min, found, nil
+}
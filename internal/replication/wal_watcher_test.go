@@ -0,0 +1,151 @@
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBadgerWAL_Watch(t *testing.T) {
+	wal, cleanup := setupTestWAL(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entries, errs := wal.Watch(ctx, 0)
+
+	for i := 0; i < 3; i++ {
+		err := wal.Append(ctx, &WALEntry{
+			TenantID:     "tenant-1",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-1",
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		select {
+		case entry := <-entries:
+			require.NotNil(t, entry)
+			assert.Equal(t, uint64(i), entry.Sequence)
+		case err := <-errs:
+			t.Fatalf("unexpected watcher error: %v", err)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for entry %d", i)
+		}
+	}
+}
+
+func TestWatcher_ResumesFromAckedPosition(t *testing.T) {
+	wal, cleanup := setupTestWAL(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		err := wal.Append(ctx, &WALEntry{
+			TenantID:     "tenant-1",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-1",
+		})
+		require.NoError(t, err)
+	}
+
+	wt, err := wal.NewWatcher(ctx, WatcherOptions{ConsumerID: "consumer-a"})
+	require.NoError(t, err)
+
+	var last *WALEntry
+	for i := 0; i < 5; i++ {
+		select {
+		case entry := <-wt.Entries():
+			last = entry
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for entry")
+		}
+	}
+	require.NoError(t, wt.Ack(ctx, last.Sequence))
+	require.NoError(t, wt.Close())
+
+	wt2, err := wal.NewWatcher(ctx, WatcherOptions{ConsumerID: "consumer-a"})
+	require.NoError(t, err)
+	defer wt2.Close()
+
+	require.NoError(t, wal.Append(ctx, &WALEntry{
+		TenantID:     "tenant-1",
+		Operation:    OperationCreate,
+		ResourceType: ResourceTypeMemory,
+		ResourceID:   "mem-2",
+	}))
+
+	select {
+	case entry := <-wt2.Entries():
+		assert.Equal(t, uint64(6), entry.Sequence)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for resumed entry")
+	}
+}
+
+func TestBadgerWAL_CloseStopsLiveWatchers(t *testing.T) {
+	wal, cleanup := setupTestWAL(t)
+	defer cleanup()
+
+	const numWatchers = 5
+
+	watchers := make([]*Watcher, numWatchers)
+	for i := range watchers {
+		// A background context means nothing external ever cancels this
+		// watcher; only wal.Close() should be able to stop it.
+		wt, err := wal.NewWatcher(context.Background(), WatcherOptions{})
+		require.NoError(t, err)
+		watchers[i] = wt
+	}
+
+	require.NoError(t, wal.Close())
+
+	for i, wt := range watchers {
+		select {
+		case _, ok := <-wt.Entries():
+			assert.False(t, ok, "watcher %d entries channel should be closed", i)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("watcher %d was not stopped by wal.Close()", i)
+		}
+	}
+}
+
+func TestBadgerWAL_TruncateBlockedByConsumer(t *testing.T) {
+	wal, cleanup := setupTestWAL(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, wal.Append(ctx, &WALEntry{
+			TenantID:     "tenant-1",
+			Operation:    OperationCreate,
+			ResourceType: ResourceTypeMemory,
+			ResourceID:   "mem-1",
+		}))
+	}
+
+	wt, err := wal.NewWatcher(ctx, WatcherOptions{ConsumerID: "consumer-a", FromSequence: 0})
+	require.NoError(t, err)
+	defer wt.Close()
+
+	require.NoError(t, wt.Ack(ctx, 2))
+
+	err = wal.Truncate(ctx, 6)
+	require.ErrorIs(t, err, ErrTruncateBlockedByConsumer)
+
+	err = wal.TruncateWithOptions(ctx, 6, TruncateOptions{Force: true})
+	require.NoError(t, err)
+
+	entries, err := wal.Read(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}